@@ -7,188 +7,349 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"strings"
 	"time"
 )
 
-const DefaultBaseURL = "https://api.loopia.se/RPCSERV"
+// Loopia hosts separate regional RPC endpoints, each serving a distinct account namespace.
+const (
+	DefaultBaseSEURL = "https://api.loopia.se/RPCSERV"
+	DefaultBaseNOURL = "https://api.loopia.no/RPCSERV"
+	DefaultBaseRSURL = "https://api.loopia.rs/RPCSERV"
+)
+
+// DefaultBaseURL is the endpoint used by NewClient.
+//
+// Deprecated: use NewClientWithRegion, or set Client.BaseURL to one of the DefaultBase*URL constants, instead.
+const DefaultBaseURL = DefaultBaseSEURL
 
 const (
 	returnOk        = "OK"
 	returnAuthError = "AUTH_ERROR"
 )
 
+// defaultMaxRetries and defaultMaxBackoff bound the exponential backoff Call applies
+// to transient failures (Loopia rate limiting, 5xx responses, network timeouts).
+const (
+	defaultMaxRetries = 3
+	defaultMaxBackoff = 30 * time.Second
+)
+
 // Client the Loopia client.
 type Client struct {
 	APIUser     string
 	APIPassword string
 	BaseURL     string
 	HTTPClient  *http.Client
+
+	// MaxRetries is how many times Call re-issues a request after a transient failure.
+	// Zero disables retries.
+	MaxRetries int
+
+	// MaxBackoff caps the exponential backoff delay Call waits between retries.
+	MaxBackoff time.Duration
 }
 
-// NewClient creates a new Loopia Client.
+// NewClient creates a new Loopia Client using the default (Swedish) API endpoint.
 func NewClient(apiUser, apiPassword string) *Client {
 	return &Client{
 		APIUser:     apiUser,
 		APIPassword: apiPassword,
 		BaseURL:     DefaultBaseURL,
 		HTTPClient:  &http.Client{Timeout: 10 * time.Second},
+		MaxRetries:  defaultMaxRetries,
+		MaxBackoff:  defaultMaxBackoff,
 	}
 }
 
-// AddTXTRecord adds a TXT record.
-func (c *Client) AddTXTRecord(domain string, subdomain string, ttl int, value string) error {
-	call := &methodCall{
-		MethodName: "addZoneRecord",
-		Params: []param{
-			paramString{Value: c.APIUser},
-			paramString{Value: c.APIPassword},
-			paramString{Value: domain},
-			paramString{Value: subdomain},
-			paramStruct{
-				StructMembers: []structMember{
-					structMemberString{
-						Name:  "type",
-						Value: "TXT",
-					}, structMemberInt{
-						Name:  "ttl",
-						Value: ttl,
-					}, structMemberInt{
-						Name:  "priority",
-						Value: 0,
-					}, structMemberString{
-						Name:  "rdata",
-						Value: value,
-					}, structMemberInt{
-						Name:  "record_id",
-						Value: 0,
-					},
-				},
-			},
-		},
-	}
-	resp := &responseString{}
-
-	err := c.rpcCall(call, resp)
+// NewClientWithRegion creates a new Loopia Client targeting the RPC endpoint for the given region.
+// region is one of "se" (Sweden, the default), "no" (Norway), or "rs" (Serbia): Loopia accounts
+// are tied to a single region, and credentials for one region's endpoint are not valid on another's.
+// An empty or unrecognized region falls back to the Swedish endpoint.
+func NewClientWithRegion(apiUser, apiPassword, region string) *Client {
+	client := NewClient(apiUser, apiPassword)
+
+	switch strings.ToLower(region) {
+	case "no":
+		client.BaseURL = DefaultBaseNOURL
+	case "rs":
+		client.BaseURL = DefaultBaseRSURL
+	default:
+		client.BaseURL = DefaultBaseSEURL
+	}
+
+	return client
+}
+
+// AddTXTRecord adds a TXT record and returns the created record's ID. addZoneRecord itself
+// only reports a status string, so the ID is resolved by re-reading the zone and matching
+// rdata; this lets callers tell their own records apart when issuing for several SANs
+// on the same subdomain concurrently.
+func (c *Client) AddTXTRecord(domain, subdomain string, ttl int, value string) (int, error) {
+	result, err := c.Call("addZoneRecord", c.APIUser, c.APIPassword, domain, subdomain, map[string]any{
+		"type":      "TXT",
+		"ttl":       ttl,
+		"priority":  0,
+		"rdata":     value,
+		"record_id": 0,
+	})
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	switch v := strings.TrimSpace(resp.Value); v {
-	case returnOk:
-		return nil
-	case returnAuthError:
-		return errors.New("authentication error")
-	default:
-		return fmt.Errorf("unknown error: %q", v)
+	if err := parseOKResponse(result.AsString()); err != nil {
+		return 0, err
+	}
+
+	records, err := c.GetZoneRecords(domain, subdomain)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, record := range records {
+		if record.Type == "TXT" && record.RData == value {
+			return record.RecordID, nil
+		}
+	}
+
+	return 0, fmt.Errorf("added TXT record but could not find its record ID")
+}
+
+// UpdateTXTRecord updates an existing TXT record in place, identified by recordID.
+// Preferring this over a remove-then-add pair avoids the brief window during renewal
+// where the record is duplicated or briefly missing.
+func (c *Client) UpdateTXTRecord(domain, subdomain string, recordID, ttl int, value string) error {
+	result, err := c.Call("updateZoneRecord", c.APIUser, c.APIPassword, domain, subdomain, map[string]any{
+		"type":      "TXT",
+		"ttl":       ttl,
+		"priority":  0,
+		"rdata":     value,
+		"record_id": recordID,
+	})
+	if err != nil {
+		return err
 	}
+
+	return parseOKResponse(result.AsString())
 }
 
 // RemoveTXTRecord removes a TXT record.
-func (c *Client) RemoveTXTRecord(domain string, subdomain string, recordID int) error {
-	call := &methodCall{
-		MethodName: "removeZoneRecord",
-		Params: []param{
-			paramString{Value: c.APIUser},
-			paramString{Value: c.APIPassword},
-			paramString{Value: domain},
-			paramString{Value: subdomain},
-			paramInt{Value: recordID},
-		},
-	}
-	resp := &responseString{}
-
-	err := c.rpcCall(call, resp)
+func (c *Client) RemoveTXTRecord(domain, subdomain string, recordID int) error {
+	result, err := c.Call("removeZoneRecord", c.APIUser, c.APIPassword, domain, subdomain, recordID)
 	if err != nil {
 		return err
 	}
 
-	switch v := strings.TrimSpace(resp.Value); v {
-	case returnOk:
-		return nil
-	case returnAuthError:
-		return fmt.Errorf("authentication error")
-	default:
-		return fmt.Errorf("unknown error: %q", v)
+	return parseOKResponse(result.AsString())
+}
+
+// GetZoneRecords gets all the zone records (of any type) for a subdomain.
+func (c *Client) GetZoneRecords(domain, subdomain string) ([]RecordObj, error) {
+	result, err := c.Call("getZoneRecords", c.APIUser, c.APIPassword, domain, subdomain)
+	if err != nil {
+		return nil, err
 	}
+
+	records := make([]RecordObj, 0, len(result.AsArray()))
+	for _, item := range result.AsArray() {
+		records = append(records, recordObjFromValue(item))
+	}
+
+	return records, nil
 }
 
 // GetTXTRecords gets TXT records.
-func (c *Client) GetTXTRecords(domain string, subdomain string) ([]RecordObj, error) {
-	call := &methodCall{
-		MethodName: "getZoneRecords",
-		Params: []param{
-			paramString{Value: c.APIUser},
-			paramString{Value: c.APIPassword},
-			paramString{Value: domain},
-			paramString{Value: subdomain},
-		},
+// It returns every zone record of the subdomain, of any type, exactly as GetZoneRecords does.
+//
+// Deprecated: use GetZoneRecords and filter by RecordObj.Type instead.
+func (c *Client) GetTXTRecords(domain, subdomain string) ([]RecordObj, error) {
+	return c.GetZoneRecords(domain, subdomain)
+}
+
+// AddSubdomain creates a subdomain under domain.
+func (c *Client) AddSubdomain(domain, subdomain string) error {
+	result, err := c.Call("addSubdomain", c.APIUser, c.APIPassword, domain, subdomain)
+	if err != nil {
+		return err
+	}
+
+	return parseOKResponse(result.AsString())
+}
+
+// GetSubdomains lists the subdomains configured under domain.
+func (c *Client) GetSubdomains(domain string) ([]string, error) {
+	result, err := c.Call("getSubdomains", c.APIUser, c.APIPassword, domain)
+	if err != nil {
+		return nil, err
 	}
-	resp := &recordObjectsResponse{}
 
-	err := c.rpcCall(call, resp)
+	subdomains := make([]string, 0, len(result.AsArray()))
+	for _, item := range result.AsArray() {
+		subdomains = append(subdomains, item.AsString())
+	}
 
-	return resp.Params, err
+	return subdomains, nil
 }
 
 // RemoveSubdomain remove a sub-domain.
 func (c *Client) RemoveSubdomain(domain, subdomain string) error {
-	call := &methodCall{
-		MethodName: "removeSubdomain",
-		Params: []param{
-			paramString{Value: c.APIUser},
-			paramString{Value: c.APIPassword},
-			paramString{Value: domain},
-			paramString{Value: subdomain},
-		},
-	}
-	resp := &responseString{}
-
-	err := c.rpcCall(call, resp)
+	result, err := c.Call("removeSubdomain", c.APIUser, c.APIPassword, domain, subdomain)
 	if err != nil {
 		return err
 	}
 
-	switch v := strings.TrimSpace(resp.Value); v {
-	case returnOk:
+	return parseOKResponse(result.AsString())
+}
+
+// GetDomains lists the domains registered on the account.
+func (c *Client) GetDomains() ([]string, error) {
+	result, err := c.Call("getDomains", c.APIUser, c.APIPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	domains := make([]string, 0, len(result.AsArray()))
+	for _, item := range result.AsArray() {
+		domains = append(domains, domainObjFromValue(item).Domain)
+	}
+
+	return domains, nil
+}
+
+// UpdateDNSServers sets the authoritative name servers for domain.
+func (c *Client) UpdateDNSServers(domain string, nameservers []string) error {
+	args := []any{c.APIUser, c.APIPassword, domain}
+	for _, ns := range nameservers {
+		args = append(args, ns)
+	}
+
+	result, err := c.Call("updateDNSServers", args...)
+	if err != nil {
+		return err
+	}
+
+	return parseOKResponse(result.AsString())
+}
+
+// parseOKResponse interprets the textual status Loopia returns from a write operation.
+func parseOKResponse(status string) error {
+	status = strings.TrimSpace(status)
+	if status == returnOk {
 		return nil
-	case returnAuthError:
-		return errors.New("authentication error")
-	default:
-		return fmt.Errorf("unknown error: %q", v)
 	}
+
+	return &RPCError{Status: status}
 }
 
-// rpcCall makes an XML-RPC call to Loopia's RPC endpoint
-// by marshaling the data given in the call argument to XML and sending that via HTTP Post to Loopia.
-// The response is then unmarshalled into the resp argument.
-func (c *Client) rpcCall(call *methodCall, resp response) error {
+// Call invokes method on the Loopia RPC endpoint with args encoded as XML-RPC values via
+// reflection (see toValue), and returns its first return value. Every Loopia method Call
+// wraps returns at most one value, so callers never need more than this.
+//
+// Loopia's well-known rate limiting, and the usual transient 5xx/timeout failures, are retried
+// with exponential backoff (bounded by Client.MaxRetries and Client.MaxBackoff) rather than
+// surfaced immediately, since issuing certificates for many SANs on one domain fires off several
+// of these calls concurrently.
+func (c *Client) Call(method string, args ...any) (Value, error) {
+	params := make([]Value, len(args))
+	for i, arg := range args {
+		v, err := toValue(arg)
+		if err != nil {
+			return Value{}, fmt.Errorf("loopia: %w", err)
+		}
+		params[i] = v
+	}
+
+	call := &valueMethodCall{MethodName: method, Params: params}
+
 	b, err := xml.MarshalIndent(call, "", "  ")
 	if err != nil {
-		return fmt.Errorf("marshal error: %w", err)
+		return Value{}, fmt.Errorf("marshal error: %w", err)
 	}
 
 	b = append([]byte(`<?xml version="1.0"?>`+"\n"), b...)
 
-	respBody, err := c.httpPost(c.BaseURL, "text/xml", bytes.NewReader(b))
-	if err != nil {
-		return err
+	maxBackoff := c.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultMaxBackoff
 	}
 
-	err = xml.Unmarshal(respBody, resp)
+	var lastErr error
+	backoff := time.Second
+
+	for attempt := 0; attempt <= c.MaxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+
+			backoff *= 2
+			if backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+		}
+
+		var value Value
+		value, lastErr = c.doCall(b)
+		if lastErr == nil || !isRetryable(lastErr) {
+			return value, lastErr
+		}
+	}
+
+	return Value{}, lastErr
+}
+
+// doCall performs a single HTTP POST/unmarshal attempt for Call.
+func (c *Client) doCall(body []byte) (Value, error) {
+	respBody, err := c.httpPost(c.BaseURL, "text/xml", bytes.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("unmarshal error: %w", err)
+		return Value{}, err
 	}
 
-	if resp.faultCode() != 0 {
-		return rpcError{
-			faultCode:   resp.faultCode(),
-			faultString: strings.TrimSpace(resp.faultString()),
-		}
+	var envelope methodResponseEnvelope
+	if err := xml.Unmarshal(respBody, &envelope); err != nil {
+		return Value{}, fmt.Errorf("unmarshal error: %w", err)
+	}
+
+	if envelope.Fault != nil {
+		return Value{}, &RPCError{FaultCode: envelope.Fault.code(), Status: envelope.Fault.status()}
+	}
+
+	value := Value{Kind: KindNil}
+	if len(envelope.Params) > 0 {
+		value = envelope.Params[0]
 	}
 
-	return nil
+	if value.Kind == KindString && isRetryableStatus(value.AsString()) {
+		return Value{}, &RPCError{Status: strings.TrimSpace(value.AsString())}
+	}
+
+	return value, nil
+}
+
+// isRetryable reports whether err, as returned by doCall, is worth retrying:
+// a rate-limited/transient RPCError, a 5xx httpStatusError, or a timed-out net.Error.
+func isRetryable(err error) bool {
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		return isRetryableStatus(rpcErr.Status)
+	}
+
+	var statusErr httpStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.code >= http.StatusInternalServerError
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// httpStatusError carries the HTTP status code from a non-200 response to Loopia's RPC endpoint.
+type httpStatusError struct {
+	code int
+}
+
+func (e httpStatusError) Error() string {
+	return fmt.Sprintf("HTTP Post Error: %d", e.code)
 }
 
 func (c *Client) httpPost(url string, bodyType string, body io.Reader) ([]byte, error) {
@@ -200,7 +361,7 @@ func (c *Client) httpPost(url string, bodyType string, body io.Reader) ([]byte,
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP Post Error: %d", resp.StatusCode)
+		return nil, httpStatusError{code: resp.StatusCode}
 	}
 
 	b, err := ioutil.ReadAll(resp.Body)
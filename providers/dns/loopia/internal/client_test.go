@@ -0,0 +1,175 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewClientWithRegion(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		region string
+		expect string
+	}{
+		{desc: "empty defaults to Sweden", region: "", expect: DefaultBaseSEURL},
+		{desc: "sweden", region: "se", expect: DefaultBaseSEURL},
+		{desc: "sweden is case-insensitive", region: "SE", expect: DefaultBaseSEURL},
+		{desc: "norway", region: "no", expect: DefaultBaseNOURL},
+		{desc: "serbia", region: "rs", expect: DefaultBaseRSURL},
+		{desc: "unknown region falls back to Sweden", region: "xx", expect: DefaultBaseSEURL},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			client := NewClientWithRegion("user", "pwd", test.region)
+
+			if client.BaseURL != test.expect {
+				t.Errorf("got BaseURL %q, want %q", client.BaseURL, test.expect)
+			}
+		})
+	}
+}
+
+const okResponse = `<?xml version="1.0"?><methodResponse><params><param><value><string>OK</string></value></param></params></methodResponse>`
+
+func rateLimitedResponse() string {
+	return `<?xml version="1.0"?><methodResponse><params><param><value><string>RATE_LIMITED</string></value></param></params></methodResponse>`
+}
+
+func TestClient_Call_retriesRateLimitedThenSucceeds(t *testing.T) {
+	var attempts int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			fmt.Fprint(w, rateLimitedResponse())
+			return
+		}
+
+		fmt.Fprint(w, okResponse)
+	}))
+	defer server.Close()
+
+	client := NewClient("user", "pwd")
+	client.BaseURL = server.URL
+	client.MaxRetries = 1
+	client.MaxBackoff = time.Second
+
+	value, err := client.Call("removeSubdomain", client.APIUser, client.APIPassword, "example.com", "foo")
+	if err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+
+	if got := value.AsString(); got != "OK" {
+		t.Errorf("got %q, want OK", got)
+	}
+
+	if attempts != 2 {
+		t.Errorf("got %d attempts, want 2", attempts)
+	}
+}
+
+func TestClient_Call_retries5xxThenSucceeds(t *testing.T) {
+	var attempts int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/xml")
+		fmt.Fprint(w, okResponse)
+	}))
+	defer server.Close()
+
+	client := NewClient("user", "pwd")
+	client.BaseURL = server.URL
+	client.MaxRetries = 1
+	client.MaxBackoff = time.Second
+
+	value, err := client.Call("removeSubdomain", client.APIUser, client.APIPassword, "example.com", "foo")
+	if err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+
+	if got := value.AsString(); got != "OK" {
+		t.Errorf("got %q, want OK", got)
+	}
+
+	if attempts != 2 {
+		t.Errorf("got %d attempts, want 2", attempts)
+	}
+}
+
+func TestClient_Call_givesUpAfterMaxRetries(t *testing.T) {
+	var attempts int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.Header().Set("Content-Type", "text/xml")
+		fmt.Fprint(w, rateLimitedResponse())
+	}))
+	defer server.Close()
+
+	client := NewClient("user", "pwd")
+	client.BaseURL = server.URL
+	client.MaxRetries = 1
+	client.MaxBackoff = time.Second
+
+	_, err := client.Call("removeSubdomain", client.APIUser, client.APIPassword, "example.com", "foo")
+	if err == nil {
+		t.Fatal("Call() expected an error after exhausting retries")
+	}
+
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("error = %v, want *RPCError", err)
+	}
+
+	if rpcErr.Status != statusRateLimited {
+		t.Errorf("got status %q, want %q", rpcErr.Status, statusRateLimited)
+	}
+
+	if attempts != 2 {
+		t.Errorf("got %d attempts, want 2 (1 initial + MaxRetries=1)", attempts)
+	}
+}
+
+func TestClient_Call_doesNotRetryNonTransientFault(t *testing.T) {
+	var attempts int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.Header().Set("Content-Type", "text/xml")
+		fmt.Fprint(w, `<?xml version="1.0"?><methodResponse><fault><value><struct>
+			<member><name>faultCode</name><value><int>1</int></value></member>
+			<member><name>faultString</name><value><string>AUTH_ERROR</string></value></member>
+		</struct></value></fault></methodResponse>`)
+	}))
+	defer server.Close()
+
+	client := NewClient("user", "pwd")
+	client.BaseURL = server.URL
+	client.MaxRetries = 2
+	client.MaxBackoff = time.Second
+
+	_, err := client.Call("removeSubdomain", client.APIUser, client.APIPassword, "example.com", "foo")
+	if err == nil {
+		t.Fatal("Call() expected an error")
+	}
+
+	if !errors.Is(err, ErrAuth) {
+		t.Errorf("errors.Is(err, ErrAuth) = false, want true (err = %v)", err)
+	}
+
+	if attempts != 1 {
+		t.Errorf("got %d attempts, want 1: AUTH_ERROR is not retryable", attempts)
+	}
+}
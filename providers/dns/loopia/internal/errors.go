@@ -0,0 +1,61 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Loopia status strings beyond OK/AUTH_ERROR, as documented by the Loopia API.
+const (
+	statusRateLimited    = "RATE_LIMITED"
+	statusBadIndata      = "BAD_INDATA"
+	statusDomainOccupied = "DOMAIN_OCCUPIED"
+	statusUnknownError   = "UNKNOWN_ERROR"
+)
+
+// Sentinel errors for the Loopia status strings callers most commonly need to branch on.
+// Use errors.Is against an *RPCError to test for these.
+var (
+	ErrAuth           = errors.New("authentication error")
+	ErrRateLimited    = errors.New("rate limited")
+	ErrBadInData      = errors.New("bad input data")
+	ErrDomainOccupied = errors.New("domain occupied")
+	ErrUnknown        = errors.New("unknown error")
+)
+
+// statusSentinels maps a Loopia status string to the sentinel error RPCError.Unwrap exposes for it.
+var statusSentinels = map[string]error{
+	returnAuthError:      ErrAuth,
+	statusRateLimited:    ErrRateLimited,
+	statusBadIndata:      ErrBadInData,
+	statusDomainOccupied: ErrDomainOccupied,
+	statusUnknownError:   ErrUnknown,
+}
+
+// RPCError represents a failure reported by the Loopia API: either an XML-RPC <fault>,
+// in which case FaultCode is nonzero, or a non-OK status string returned inside an
+// otherwise well-formed methodResponse.
+type RPCError struct {
+	FaultCode int
+	Status    string
+}
+
+func (e *RPCError) Error() string {
+	if e.FaultCode != 0 {
+		return fmt.Sprintf("loopia: rpc fault %d: %s", e.FaultCode, e.Status)
+	}
+
+	return fmt.Sprintf("loopia: %s", e.Status)
+}
+
+// Unwrap exposes the sentinel error matching e.Status, if any, so callers can use errors.Is
+// without having to compare status strings themselves.
+func (e *RPCError) Unwrap() error {
+	return statusSentinels[e.Status]
+}
+
+// isRetryableStatus reports whether status is known to be transient and worth retrying.
+func isRetryableStatus(status string) bool {
+	return strings.TrimSpace(status) == statusRateLimited
+}
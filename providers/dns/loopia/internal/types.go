@@ -0,0 +1,15 @@
+package internal
+
+// RecordObj is a Loopia DNS zone record, as returned by getZoneRecords.
+type RecordObj struct {
+	Type     string
+	TTL      int
+	Priority int
+	RData    string
+	RecordID int
+}
+
+// DomainObj is a Loopia domain entry, as returned by getDomains.
+type DomainObj struct {
+	Domain string
+}
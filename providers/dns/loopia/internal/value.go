@@ -0,0 +1,443 @@
+package internal
+
+import (
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateTimeLayout is the format XML-RPC uses for <dateTime.iso8601> values.
+const dateTimeLayout = "20060102T15:04:05"
+
+// Kind identifies which of Value's fields is meaningful.
+type Kind int
+
+// The XML-RPC scalar and compound types Loopia's API is documented to use.
+const (
+	KindNil Kind = iota
+	KindString
+	KindInt
+	KindBoolean
+	KindDouble
+	KindDateTime
+	KindArray
+	KindStruct
+)
+
+// Value is a single XML-RPC value. It replaces the old fixed set of paramString/paramInt/
+// paramStruct/responseString/recordObjectsResponse types with one value model that can
+// marshal or unmarshal any shape Loopia's API returns, so adding a new RPC method no longer
+// requires a new hand-written response type.
+type Value struct {
+	Kind Kind
+
+	str         string
+	num         int
+	boolean     bool
+	double      float64
+	when        time.Time
+	array       []Value
+	strct       map[string]Value
+	structOrder []string
+}
+
+// AsString returns the value's string content. It is the zero value unless Kind is KindString.
+func (v Value) AsString() string { return v.str }
+
+// AsInt returns the value's int content. It is the zero value unless Kind is KindInt.
+func (v Value) AsInt() int { return v.num }
+
+// AsBool returns the value's boolean content. It is the zero value unless Kind is KindBoolean.
+func (v Value) AsBool() bool { return v.boolean }
+
+// AsDouble returns the value's float content. It is the zero value unless Kind is KindDouble.
+func (v Value) AsDouble() float64 { return v.double }
+
+// AsTime returns the value's time content. It is the zero value unless Kind is KindDateTime.
+func (v Value) AsTime() time.Time { return v.when }
+
+// AsArray returns the value's elements. It is nil unless Kind is KindArray.
+func (v Value) AsArray() []Value { return v.array }
+
+// Member returns the named struct member and whether it was present. It always reports
+// false unless Kind is KindStruct.
+func (v Value) Member(name string) (Value, bool) {
+	member, ok := v.strct[name]
+	return member, ok
+}
+
+// IsNil reports whether the value is an XML-RPC <nil/>.
+func (v Value) IsNil() bool { return v.Kind == KindNil }
+
+// NewStringValue builds a KindString Value.
+func NewStringValue(s string) Value { return Value{Kind: KindString, str: s} }
+
+// NewIntValue builds a KindInt Value.
+func NewIntValue(n int) Value { return Value{Kind: KindInt, num: n} }
+
+// toValue converts a Go value into its XML-RPC Value using reflection, so Call callers
+// can pass plain strings, ints, slices, maps, and structs instead of building one themselves.
+func toValue(arg any) (Value, error) {
+	if arg == nil {
+		return Value{Kind: KindNil}, nil
+	}
+
+	if v, ok := arg.(Value); ok {
+		return v, nil
+	}
+
+	if t, ok := arg.(time.Time); ok {
+		return Value{Kind: KindDateTime, when: t}, nil
+	}
+
+	rv := reflect.ValueOf(arg)
+
+	switch rv.Kind() {
+	case reflect.String:
+		return Value{Kind: KindString, str: rv.String()}, nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return Value{Kind: KindInt, num: int(rv.Int())}, nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Value{Kind: KindInt, num: int(rv.Uint())}, nil
+
+	case reflect.Bool:
+		return Value{Kind: KindBoolean, boolean: rv.Bool()}, nil
+
+	case reflect.Float32, reflect.Float64:
+		return Value{Kind: KindDouble, double: rv.Float()}, nil
+
+	case reflect.Slice, reflect.Array:
+		items := make([]Value, rv.Len())
+		for i := range items {
+			item, err := toValue(rv.Index(i).Interface())
+			if err != nil {
+				return Value{}, err
+			}
+			items[i] = item
+		}
+		return Value{Kind: KindArray, array: items}, nil
+
+	case reflect.Map:
+		return mapToValue(rv)
+
+	case reflect.Struct:
+		return structToValue(rv)
+
+	default:
+		return Value{}, fmt.Errorf("xmlrpc: unsupported argument type %T", arg)
+	}
+}
+
+func mapToValue(rv reflect.Value) (Value, error) {
+	keys := rv.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+	})
+
+	strct := make(map[string]Value, len(keys))
+	order := make([]string, 0, len(keys))
+
+	for _, key := range keys {
+		name := fmt.Sprint(key.Interface())
+
+		member, err := toValue(rv.MapIndex(key).Interface())
+		if err != nil {
+			return Value{}, err
+		}
+
+		strct[name] = member
+		order = append(order, name)
+	}
+
+	return Value{Kind: KindStruct, strct: strct, structOrder: order}, nil
+}
+
+func structToValue(rv reflect.Value) (Value, error) {
+	rt := rv.Type()
+
+	strct := make(map[string]Value, rt.NumField())
+	order := make([]string, 0, rt.NumField())
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+
+		name := field.Tag.Get("xmlrpc")
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		member, err := toValue(rv.Field(i).Interface())
+		if err != nil {
+			return Value{}, err
+		}
+
+		strct[name] = member
+		order = append(order, name)
+	}
+
+	return Value{Kind: KindStruct, strct: strct, structOrder: order}, nil
+}
+
+// MarshalXML encodes v as an XML-RPC <value>.
+func (v Value) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name = xml.Name{Local: "value"}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch v.Kind {
+	case KindNil:
+		nilStart := xml.StartElement{Name: xml.Name{Local: "nil"}}
+		if err := e.EncodeToken(nilStart); err != nil {
+			return err
+		}
+		if err := e.EncodeToken(nilStart.End()); err != nil {
+			return err
+		}
+
+	case KindString:
+		if err := e.EncodeElement(v.str, xml.StartElement{Name: xml.Name{Local: "string"}}); err != nil {
+			return err
+		}
+
+	case KindInt:
+		if err := e.EncodeElement(v.num, xml.StartElement{Name: xml.Name{Local: "int"}}); err != nil {
+			return err
+		}
+
+	case KindBoolean:
+		b := 0
+		if v.boolean {
+			b = 1
+		}
+		if err := e.EncodeElement(b, xml.StartElement{Name: xml.Name{Local: "boolean"}}); err != nil {
+			return err
+		}
+
+	case KindDouble:
+		s := strconv.FormatFloat(v.double, 'f', -1, 64)
+		if err := e.EncodeElement(s, xml.StartElement{Name: xml.Name{Local: "double"}}); err != nil {
+			return err
+		}
+
+	case KindDateTime:
+		s := v.when.Format(dateTimeLayout)
+		if err := e.EncodeElement(s, xml.StartElement{Name: xml.Name{Local: "dateTime.iso8601"}}); err != nil {
+			return err
+		}
+
+	case KindArray:
+		if err := marshalArray(e, v.array); err != nil {
+			return err
+		}
+
+	case KindStruct:
+		if err := marshalStruct(e, v); err != nil {
+			return err
+		}
+	}
+
+	return e.EncodeToken(start.End())
+}
+
+func marshalArray(e *xml.Encoder, items []Value) error {
+	arrayStart := xml.StartElement{Name: xml.Name{Local: "array"}}
+	if err := e.EncodeToken(arrayStart); err != nil {
+		return err
+	}
+
+	dataStart := xml.StartElement{Name: xml.Name{Local: "data"}}
+	if err := e.EncodeToken(dataStart); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if err := e.Encode(item); err != nil {
+			return err
+		}
+	}
+
+	if err := e.EncodeToken(dataStart.End()); err != nil {
+		return err
+	}
+
+	return e.EncodeToken(arrayStart.End())
+}
+
+func marshalStruct(e *xml.Encoder, v Value) error {
+	structStart := xml.StartElement{Name: xml.Name{Local: "struct"}}
+	if err := e.EncodeToken(structStart); err != nil {
+		return err
+	}
+
+	for _, name := range v.structOrder {
+		memberStart := xml.StartElement{Name: xml.Name{Local: "member"}}
+		if err := e.EncodeToken(memberStart); err != nil {
+			return err
+		}
+
+		if err := e.EncodeElement(name, xml.StartElement{Name: xml.Name{Local: "name"}}); err != nil {
+			return err
+		}
+
+		if err := e.Encode(v.strct[name]); err != nil {
+			return err
+		}
+
+		if err := e.EncodeToken(memberStart.End()); err != nil {
+			return err
+		}
+	}
+
+	return e.EncodeToken(structStart.End())
+}
+
+// UnmarshalXML decodes an XML-RPC <value> into v, inferring its Kind from whichever
+// typed child element (or bare character data) is present.
+func (v *Value) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var raw struct {
+		String   *string `xml:"string"`
+		Int      *string `xml:"int"`
+		I4       *string `xml:"i4"`
+		Boolean  *string `xml:"boolean"`
+		Double   *string `xml:"double"`
+		DateTime *string `xml:"dateTime.iso8601"`
+		Nil      *struct{} `xml:"nil"`
+		Array    *struct {
+			Data struct {
+				Values []Value `xml:"value"`
+			} `xml:"data"`
+		} `xml:"array"`
+		Struct *struct {
+			Members []struct {
+				Name  string `xml:"name"`
+				Value Value  `xml:"value"`
+			} `xml:"member"`
+		} `xml:"struct"`
+		CharData string `xml:",chardata"`
+	}
+
+	if err := d.DecodeElement(&raw, &start); err != nil {
+		return err
+	}
+
+	switch {
+	case raw.Nil != nil:
+		*v = Value{Kind: KindNil}
+
+	case raw.String != nil:
+		*v = Value{Kind: KindString, str: *raw.String}
+
+	case raw.Int != nil:
+		n, _ := strconv.Atoi(strings.TrimSpace(*raw.Int))
+		*v = Value{Kind: KindInt, num: n}
+
+	case raw.I4 != nil:
+		n, _ := strconv.Atoi(strings.TrimSpace(*raw.I4))
+		*v = Value{Kind: KindInt, num: n}
+
+	case raw.Boolean != nil:
+		*v = Value{Kind: KindBoolean, boolean: strings.TrimSpace(*raw.Boolean) == "1"}
+
+	case raw.Double != nil:
+		f, _ := strconv.ParseFloat(strings.TrimSpace(*raw.Double), 64)
+		*v = Value{Kind: KindDouble, double: f}
+
+	case raw.DateTime != nil:
+		t, _ := time.Parse(dateTimeLayout, strings.TrimSpace(*raw.DateTime))
+		*v = Value{Kind: KindDateTime, when: t}
+
+	case raw.Array != nil:
+		*v = Value{Kind: KindArray, array: raw.Array.Data.Values}
+
+	case raw.Struct != nil:
+		strct := make(map[string]Value, len(raw.Struct.Members))
+		order := make([]string, 0, len(raw.Struct.Members))
+		for _, member := range raw.Struct.Members {
+			strct[member.Name] = member.Value
+			order = append(order, member.Name)
+		}
+		*v = Value{Kind: KindStruct, strct: strct, structOrder: order}
+
+	default:
+		// XML-RPC permits a bare string with no <string> wrapper.
+		*v = Value{Kind: KindString, str: strings.TrimSpace(raw.CharData)}
+	}
+
+	return nil
+}
+
+// valueMethodCall is the XML-RPC request envelope used by Call.
+type valueMethodCall struct {
+	XMLName    xml.Name `xml:"methodCall"`
+	MethodName string   `xml:"methodName"`
+	Params     []Value  `xml:"params>param>value"`
+}
+
+// methodResponseEnvelope is the XML-RPC response envelope used by Call: either Params
+// holds the method's return value(s), or Fault holds the XML-RPC fault struct.
+type methodResponseEnvelope struct {
+	XMLName xml.Name    `xml:"methodResponse"`
+	Params  []Value     `xml:"params>param>value"`
+	Fault   *faultValue `xml:"fault"`
+}
+
+// faultValue decodes the <fault><value><struct>...</struct></value></fault> Loopia sends
+// for XML-RPC faults.
+type faultValue struct {
+	Value Value `xml:"value"`
+}
+
+func (f faultValue) code() int {
+	if member, ok := f.Value.Member("faultCode"); ok {
+		return member.AsInt()
+	}
+	return 0
+}
+
+func (f faultValue) status() string {
+	if member, ok := f.Value.Member("faultString"); ok {
+		return strings.TrimSpace(member.AsString())
+	}
+	return ""
+}
+
+// recordObjFromValue converts a record_obj <struct> Value into a RecordObj.
+func recordObjFromValue(v Value) RecordObj {
+	var record RecordObj
+
+	if m, ok := v.Member("type"); ok {
+		record.Type = m.AsString()
+	}
+	if m, ok := v.Member("ttl"); ok {
+		record.TTL = m.AsInt()
+	}
+	if m, ok := v.Member("priority"); ok {
+		record.Priority = m.AsInt()
+	}
+	if m, ok := v.Member("rdata"); ok {
+		record.RData = m.AsString()
+	}
+	if m, ok := v.Member("record_id"); ok {
+		record.RecordID = m.AsInt()
+	}
+
+	return record
+}
+
+// domainObjFromValue converts a domain <struct> Value into a DomainObj.
+func domainObjFromValue(v Value) DomainObj {
+	var domain DomainObj
+
+	if m, ok := v.Member("domain"); ok {
+		domain.Domain = m.AsString()
+	}
+
+	return domain
+}
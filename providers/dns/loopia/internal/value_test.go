@@ -0,0 +1,237 @@
+package internal
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+)
+
+func TestValue_roundTrip(t *testing.T) {
+	testCases := []struct {
+		desc string
+		in   Value
+	}{
+		{desc: "string", in: NewStringValue("hello world")},
+		{desc: "int", in: NewIntValue(42)},
+		{desc: "boolean true", in: Value{Kind: KindBoolean, boolean: true}},
+		{desc: "boolean false", in: Value{Kind: KindBoolean, boolean: false}},
+		{desc: "double", in: Value{Kind: KindDouble, double: 3.14}},
+		{desc: "nil", in: Value{Kind: KindNil}},
+		{desc: "array", in: Value{Kind: KindArray, array: []Value{NewStringValue("a"), NewIntValue(1)}}},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			b, err := xml.Marshal(test.in)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+
+			var out Value
+			if err := xml.Unmarshal(b, &out); err != nil {
+				t.Fatalf("Unmarshal() error = %v\nxml: %s", err, b)
+			}
+
+			if out.Kind != test.in.Kind {
+				t.Fatalf("got Kind %v, want %v", out.Kind, test.in.Kind)
+			}
+
+			switch test.in.Kind {
+			case KindString:
+				if out.AsString() != test.in.AsString() {
+					t.Errorf("got %q, want %q", out.AsString(), test.in.AsString())
+				}
+			case KindInt:
+				if out.AsInt() != test.in.AsInt() {
+					t.Errorf("got %d, want %d", out.AsInt(), test.in.AsInt())
+				}
+			case KindBoolean:
+				if out.AsBool() != test.in.AsBool() {
+					t.Errorf("got %v, want %v", out.AsBool(), test.in.AsBool())
+				}
+			case KindDouble:
+				if out.AsDouble() != test.in.AsDouble() {
+					t.Errorf("got %v, want %v", out.AsDouble(), test.in.AsDouble())
+				}
+			case KindArray:
+				if len(out.AsArray()) != len(test.in.AsArray()) {
+					t.Errorf("got %d elements, want %d", len(out.AsArray()), len(test.in.AsArray()))
+				}
+			}
+		})
+	}
+}
+
+func TestValue_dateTimeRoundTrip(t *testing.T) {
+	in := Value{Kind: KindDateTime, when: time.Date(2024, 3, 2, 15, 4, 5, 0, time.UTC)}
+
+	b, err := xml.Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	var out Value
+	if err := xml.Unmarshal(b, &out); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if !out.AsTime().Equal(in.AsTime()) {
+		t.Errorf("got %v, want %v", out.AsTime(), in.AsTime())
+	}
+}
+
+func TestValue_structRoundTrip(t *testing.T) {
+	in, err := toValue(map[string]any{"type": "TXT", "ttl": 300, "rdata": "abc"})
+	if err != nil {
+		t.Fatalf("toValue() error = %v", err)
+	}
+
+	b, err := xml.Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	var out Value
+	if err := xml.Unmarshal(b, &out); err != nil {
+		t.Fatalf("Unmarshal() error = %v\nxml: %s", err, b)
+	}
+
+	record := recordObjFromValue(out)
+	if record.Type != "TXT" || record.TTL != 300 || record.RData != "abc" {
+		t.Errorf("got %+v", record)
+	}
+}
+
+// TestValue_unmarshalDocumentedRecordObjArray decodes a methodResponse shaped exactly as
+// Loopia's getZoneRecords is documented to return: an array of record_obj structs.
+func TestValue_unmarshalDocumentedRecordObjArray(t *testing.T) {
+	const body = `<?xml version="1.0"?>
+<methodResponse>
+  <params>
+    <param>
+      <value>
+        <array>
+          <data>
+            <value>
+              <struct>
+                <member><name>type</name><value><string>TXT</string></value></member>
+                <member><name>ttl</name><value><int>300</int></value></member>
+                <member><name>priority</name><value><int>0</int></value></member>
+                <member><name>rdata</name><value><string>some-challenge-value</string></value></member>
+                <member><name>record_id</name><value><int>12345</int></value></member>
+              </struct>
+            </value>
+          </data>
+        </array>
+      </value>
+    </param>
+  </params>
+</methodResponse>`
+
+	var envelope methodResponseEnvelope
+	if err := xml.Unmarshal([]byte(body), &envelope); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if len(envelope.Params) != 1 {
+		t.Fatalf("got %d params, want 1", len(envelope.Params))
+	}
+
+	records := envelope.Params[0].AsArray()
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1", len(records))
+	}
+
+	record := recordObjFromValue(records[0])
+	if record.Type != "TXT" || record.TTL != 300 || record.RData != "some-challenge-value" || record.RecordID != 12345 {
+		t.Errorf("got %+v", record)
+	}
+}
+
+func TestValue_unmarshalFault(t *testing.T) {
+	const body = `<?xml version="1.0"?>
+<methodResponse>
+  <fault>
+    <value>
+      <struct>
+        <member><name>faultCode</name><value><int>1</int></value></member>
+        <member><name>faultString</name><value><string>AUTH_ERROR</string></value></member>
+      </struct>
+    </value>
+  </fault>
+</methodResponse>`
+
+	var envelope methodResponseEnvelope
+	if err := xml.Unmarshal([]byte(body), &envelope); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if envelope.Fault == nil {
+		t.Fatal("expected a fault")
+	}
+
+	if got := envelope.Fault.code(); got != 1 {
+		t.Errorf("got faultCode %d, want 1", got)
+	}
+
+	if got := envelope.Fault.status(); got != "AUTH_ERROR" {
+		t.Errorf("got faultString %q, want AUTH_ERROR", got)
+	}
+}
+
+// TestValue_unmarshalBareString covers the case the default branch of UnmarshalXML exists
+// for: XML-RPC permits a bare string with no <string> wrapper.
+func TestValue_unmarshalBareString(t *testing.T) {
+	const body = `<?xml version="1.0"?><value>  OK  </value>`
+
+	var v Value
+	if err := xml.Unmarshal([]byte(body), &v); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if v.Kind != KindString || v.AsString() != "OK" {
+		t.Errorf("got %+v, want trimmed bare string OK", v)
+	}
+}
+
+// TestValue_unmarshalStringPreservesContent guards against re-introducing a generic trim of
+// <string> content in the decoder: Value is reused by arbitrary future RPC methods, and only
+// Loopia's own status parsing (parseOKResponse, isRetryableStatus, faultValue.status) should
+// trim whitespace.
+func TestValue_unmarshalStringPreservesContent(t *testing.T) {
+	const body = `<?xml version="1.0"?><value><string>  padded rdata  </string></value>`
+
+	var v Value
+	if err := xml.Unmarshal([]byte(body), &v); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if v.AsString() != "  padded rdata  " {
+		t.Errorf("got %q, want content preserved verbatim", v.AsString())
+	}
+}
+
+// TestValue_unmarshalFaultTrimsStatus ensures a whitespace-padded faultString still matches
+// the RPCError sentinel lookup, even though the decoder itself no longer trims <string>.
+func TestValue_unmarshalFaultTrimsStatus(t *testing.T) {
+	const body = `<?xml version="1.0"?>
+<methodResponse>
+  <fault>
+    <value>
+      <struct>
+        <member><name>faultCode</name><value><int>1</int></value></member>
+        <member><name>faultString</name><value><string>  AUTH_ERROR  </string></value></member>
+      </struct>
+    </value>
+  </fault>
+</methodResponse>`
+
+	var envelope methodResponseEnvelope
+	if err := xml.Unmarshal([]byte(body), &envelope); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if got := envelope.Fault.status(); got != "AUTH_ERROR" {
+		t.Errorf("got faultString %q, want trimmed AUTH_ERROR", got)
+	}
+}
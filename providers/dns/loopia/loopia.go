@@ -0,0 +1,283 @@
+// Package loopia implements a DNS provider for solving the DNS-01 challenge using Loopia DNS.
+package loopia
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-acme/lego/v4/challenge/dns01"
+	"github.com/go-acme/lego/v4/platform/config/env"
+	"github.com/go-acme/lego/v4/providers/dns/loopia/internal"
+)
+
+// Environment variables names.
+const (
+	envNamespace = "LOOPIA_"
+
+	EnvAPIUser     = envNamespace + "API_USER"
+	EnvAPIPassword = envNamespace + "API_PASSWORD"
+	EnvAPIURL      = envNamespace + "API_URL"
+	EnvRegion      = envNamespace + "REGION"
+
+	EnvPollingInterval    = envNamespace + "POLLING_INTERVAL"
+	EnvPropagationTimeout = envNamespace + "PROPAGATION_TIMEOUT"
+	EnvTTL                = envNamespace + "TTL"
+	EnvHTTPTimeout        = envNamespace + "HTTP_TIMEOUT"
+)
+
+// minTTL is the smallest TTL Loopia accepts on a zone record.
+const minTTL = 300
+
+// Config is used to configure the creation of the DNSProvider.
+type Config struct {
+	APIUser     string
+	APIPassword string
+
+	// APIURL overrides the endpoint derived from Region, e.g. for testing against a mock server.
+	APIURL string
+
+	// Region selects the Loopia RPC endpoint: "se" (default), "no", or "rs".
+	// It is ignored when APIURL is set.
+	Region string
+
+	PropagationTimeout time.Duration
+	PollingInterval    time.Duration
+	TTL                int
+	HTTPClient         *http.Client
+}
+
+// NewDefaultConfig returns a default configuration for the DNSProvider.
+func NewDefaultConfig() *Config {
+	return &Config{
+		TTL:                env.GetOrDefaultInt(EnvTTL, minTTL),
+		PropagationTimeout: env.GetOrDefaultSecond(EnvPropagationTimeout, 40*time.Minute),
+		PollingInterval:    env.GetOrDefaultSecond(EnvPollingInterval, 60*time.Second),
+		HTTPClient: &http.Client{
+			Timeout: env.GetOrDefaultSecond(EnvHTTPTimeout, 60*time.Second),
+		},
+	}
+}
+
+// DNSProvider implements the challenge.Provider interface.
+type DNSProvider struct {
+	config *Config
+	client *internal.Client
+
+	// recordIDs tracks, per challenge FQDN and TXT value, the record ID Present created for
+	// it. It is keyed on both because two different authorizations (e.g. the apex and a
+	// wildcard) resolve to the identical _acme-challenge FQDN and can be presented and
+	// cleaned up concurrently: keying on FQDN alone would let one of them clobber the
+	// other's record ID. It is guarded by recordIDsMu since lego calls Present/CleanUp
+	// concurrently when issuing a certificate covering several SANs under one apex domain.
+	recordIDs   map[string]map[string]int
+	recordIDsMu sync.Mutex
+}
+
+// NewDNSProvider returns a DNSProvider instance configured for Loopia.
+// Credentials are given by LOOPIA_API_USER and LOOPIA_API_PASSWORD environment variables.
+// LOOPIA_REGION ("se", "no", or "rs") selects the regional endpoint for the account;
+// LOOPIA_API_URL overrides it outright, which takes precedence over LOOPIA_REGION.
+func NewDNSProvider() (*DNSProvider, error) {
+	values, err := env.Get(EnvAPIUser, EnvAPIPassword)
+	if err != nil {
+		return nil, fmt.Errorf("loopia: %w", err)
+	}
+
+	config := NewDefaultConfig()
+	config.APIUser = values[EnvAPIUser]
+	config.APIPassword = values[EnvAPIPassword]
+	config.APIURL = env.GetOrDefaultString(EnvAPIURL, "")
+	config.Region = env.GetOrDefaultString(EnvRegion, "")
+
+	return NewDNSProviderConfig(config)
+}
+
+// NewDNSProviderConfig return a DNSProvider instance configured for Loopia.
+func NewDNSProviderConfig(config *Config) (*DNSProvider, error) {
+	if config == nil {
+		return nil, errors.New("loopia: the configuration of the DNS provider is nil")
+	}
+
+	if config.APIUser == "" || config.APIPassword == "" {
+		return nil, errors.New("loopia: credentials missing")
+	}
+
+	if config.TTL < minTTL {
+		return nil, fmt.Errorf("loopia: invalid TTL, TTL (%d) must be greater than or equal to %d", config.TTL, minTTL)
+	}
+
+	client := internal.NewClientWithRegion(config.APIUser, config.APIPassword, config.Region)
+	if config.APIURL != "" {
+		client.BaseURL = config.APIURL
+	}
+
+	if config.HTTPClient != nil {
+		client.HTTPClient = config.HTTPClient
+	}
+
+	return &DNSProvider{config: config, client: client, recordIDs: make(map[string]map[string]int)}, nil
+}
+
+// Timeout returns the timeout and interval to use when checking for DNS propagation.
+func (d *DNSProvider) Timeout() (timeout, interval time.Duration) {
+	return d.config.PropagationTimeout, d.config.PollingInterval
+}
+
+// Present creates a TXT record to fulfill the dns-01 challenge.
+// If this provider instance already created a TXT record for this exact challenge FQDN and value
+// earlier in the process, and that record is still in the zone (e.g. a retried Present), it is updated in place via UpdateTXTRecord.
+// Otherwise a new record is always added alongside whatever is already there: two different
+// authorizations (e.g. the apex and a wildcard) resolve to the identical _acme-challenge FQDN
+// and can be presented concurrently, so an unconditional "update whatever TXT record is there"
+// would let one authorization's Present overwrite the other's value.
+func (d *DNSProvider) Present(domain, token, keyAuth string) error {
+	info := dns01.GetChallengeInfo(domain, keyAuth)
+
+	authZone, err := dns01.FindZoneByFqdn(info.EffectiveFQDN)
+	if err != nil {
+		return fmt.Errorf("loopia: could not find zone for domain %q: %w", domain, err)
+	}
+
+	apexDomain := dns01.UnFqdn(authZone)
+	subdomain := extractSubdomain(info.EffectiveFQDN, authZone)
+
+	return d.present(apexDomain, subdomain, info.EffectiveFQDN, info.Value)
+}
+
+// present does the work of Present once the zone has been resolved, so it can be exercised
+// against a fake Loopia server without depending on Present's real DNS zone lookup.
+func (d *DNSProvider) present(apexDomain, subdomain, fqdn, value string) error {
+	if recordID, ok := d.lookupRecordID(fqdn, value); ok {
+		records, err := d.client.GetZoneRecords(apexDomain, subdomain)
+		if err != nil {
+			return fmt.Errorf("loopia: %w", err)
+		}
+
+		for _, record := range records {
+			if record.Type != "TXT" || record.RecordID != recordID {
+				continue
+			}
+
+			if err := d.client.UpdateTXTRecord(apexDomain, subdomain, recordID, d.config.TTL, value); err != nil {
+				return fmt.Errorf("loopia: %w", err)
+			}
+
+			d.storeRecordID(fqdn, value, recordID)
+
+			return nil
+		}
+	}
+
+	recordID, err := d.client.AddTXTRecord(apexDomain, subdomain, d.config.TTL, value)
+	if err != nil {
+		return fmt.Errorf("loopia: %w", err)
+	}
+
+	d.storeRecordID(fqdn, value, recordID)
+
+	return nil
+}
+
+// CleanUp removes the TXT record Present created for this challenge, identified by the record
+// ID Present recorded for it rather than by matching rdata, so concurrent CleanUp calls for
+// different SANs sharing an apex domain never race each other into deleting the wrong record.
+// Only when no record ID was recorded for it is the record looked up by matching rdata.
+// The enclosing _acme-challenge subdomain is only removed once it has no zone records left.
+func (d *DNSProvider) CleanUp(domain, token, keyAuth string) error {
+	info := dns01.GetChallengeInfo(domain, keyAuth)
+
+	authZone, err := dns01.FindZoneByFqdn(info.EffectiveFQDN)
+	if err != nil {
+		return fmt.Errorf("loopia: could not find zone for domain %q: %w", domain, err)
+	}
+
+	apexDomain := dns01.UnFqdn(authZone)
+	subdomain := extractSubdomain(info.EffectiveFQDN, authZone)
+
+	return d.cleanUp(apexDomain, subdomain, info.EffectiveFQDN, info.Value)
+}
+
+// cleanUp does the work of CleanUp once the zone has been resolved, so it can be exercised
+// against a fake Loopia server without depending on CleanUp's real DNS zone lookup.
+func (d *DNSProvider) cleanUp(apexDomain, subdomain, fqdn, value string) error {
+	recordID, ok := d.takeRecordID(fqdn, value)
+	if !ok {
+		// We never recorded this FQDN/value pair's record ID (e.g. the process restarted
+		// between Present and CleanUp): fall back to matching by rdata.
+		records, err := d.client.GetZoneRecords(apexDomain, subdomain)
+		if err != nil {
+			return fmt.Errorf("loopia: %w", err)
+		}
+
+		for _, record := range records {
+			if record.Type == "TXT" && record.RData == value {
+				recordID, ok = record.RecordID, true
+				break
+			}
+		}
+	}
+
+	if ok {
+		if err := d.client.RemoveTXTRecord(apexDomain, subdomain, recordID); err != nil {
+			return fmt.Errorf("loopia: %w", err)
+		}
+	}
+
+	records, err := d.client.GetZoneRecords(apexDomain, subdomain)
+	if err != nil {
+		return fmt.Errorf("loopia: %w", err)
+	}
+
+	if len(records) > 0 {
+		return nil
+	}
+
+	if err := d.client.RemoveSubdomain(apexDomain, subdomain); err != nil {
+		return fmt.Errorf("loopia: %w", err)
+	}
+
+	return nil
+}
+
+func (d *DNSProvider) storeRecordID(fqdn, value string, recordID int) {
+	d.recordIDsMu.Lock()
+	defer d.recordIDsMu.Unlock()
+
+	if d.recordIDs[fqdn] == nil {
+		d.recordIDs[fqdn] = make(map[string]int)
+	}
+
+	d.recordIDs[fqdn][value] = recordID
+}
+
+func (d *DNSProvider) lookupRecordID(fqdn, value string) (int, bool) {
+	d.recordIDsMu.Lock()
+	defer d.recordIDsMu.Unlock()
+
+	recordID, ok := d.recordIDs[fqdn][value]
+
+	return recordID, ok
+}
+
+func (d *DNSProvider) takeRecordID(fqdn, value string) (int, bool) {
+	d.recordIDsMu.Lock()
+	defer d.recordIDsMu.Unlock()
+
+	recordID, ok := d.recordIDs[fqdn][value]
+	if ok {
+		delete(d.recordIDs[fqdn], value)
+		if len(d.recordIDs[fqdn]) == 0 {
+			delete(d.recordIDs, fqdn)
+		}
+	}
+
+	return recordID, ok
+}
+
+func extractSubdomain(fqdn, zone string) string {
+	return dns01.UnFqdn(strings.TrimSuffix(fqdn, zone))
+}
@@ -0,0 +1,242 @@
+package loopia
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeRPCCall is just enough of the XML-RPC wire shape (see internal.Value) to read back the
+// method name and the string/int arguments the DNSProvider methods under test actually send.
+type fakeRPCCall struct {
+	MethodName string `xml:"methodName"`
+	Params     []struct {
+		Value struct {
+			String string `xml:"string"`
+			Int    string `xml:"int"`
+			Struct struct {
+				Members []struct {
+					Name  string `xml:"name"`
+					Value struct {
+						String string `xml:"string"`
+						Int    string `xml:"int"`
+					} `xml:"value"`
+				} `xml:"member"`
+			} `xml:"struct"`
+		} `xml:"value"`
+	} `xml:"params>param"`
+}
+
+func (c fakeRPCCall) member(name string) string {
+	for _, p := range c.Params {
+		for _, m := range p.Value.Struct.Members {
+			if m.Name == name {
+				if m.Value.String != "" {
+					return m.Value.String
+				}
+				return m.Value.Int
+			}
+		}
+	}
+	return ""
+}
+
+// fakeRecord is one zone record held by fakeLoopiaServer.
+type fakeRecord struct {
+	recordID int
+	rdata    string
+}
+
+// fakeLoopiaServer is a minimal in-memory stand-in for the Loopia RPC endpoint, keyed by
+// "domain|subdomain", supporting just the methods Present/CleanUp exercise.
+type fakeLoopiaServer struct {
+	mu      sync.Mutex
+	records map[string][]fakeRecord
+	nextID  int32
+}
+
+func newFakeLoopiaServer() *fakeLoopiaServer {
+	return &fakeLoopiaServer{records: make(map[string][]fakeRecord)}
+}
+
+func (s *fakeLoopiaServer) key(call fakeRPCCall) string {
+	if len(call.Params) < 4 {
+		return ""
+	}
+	return call.Params[2].Value.String + "|" + call.Params[3].Value.String
+}
+
+func (s *fakeLoopiaServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var call fakeRPCCall
+	if err := xml.NewDecoder(r.Body).Decode(&call); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/xml")
+
+	switch call.MethodName {
+	case "addZoneRecord":
+		key := s.key(call)
+
+		s.mu.Lock()
+		id := int(atomic.AddInt32(&s.nextID, 1))
+		s.records[key] = append(s.records[key], fakeRecord{recordID: id, rdata: call.member("rdata")})
+		s.mu.Unlock()
+
+		fmt.Fprint(w, okResponse())
+
+	case "updateZoneRecord":
+		key := s.key(call)
+		recordID := call.member("record_id")
+
+		s.mu.Lock()
+		for i, rec := range s.records[key] {
+			if fmt.Sprint(rec.recordID) == recordID {
+				s.records[key][i].rdata = call.member("rdata")
+			}
+		}
+		s.mu.Unlock()
+
+		fmt.Fprint(w, okResponse())
+
+	case "removeZoneRecord":
+		key := s.key(call)
+		recordID := call.Params[len(call.Params)-1].Value.Int
+
+		s.mu.Lock()
+		kept := s.records[key][:0]
+		for _, rec := range s.records[key] {
+			if fmt.Sprint(rec.recordID) != recordID {
+				kept = append(kept, rec)
+			}
+		}
+		s.records[key] = kept
+		s.mu.Unlock()
+
+		fmt.Fprint(w, okResponse())
+
+	case "getZoneRecords":
+		key := s.key(call)
+
+		s.mu.Lock()
+		records := append([]fakeRecord(nil), s.records[key]...)
+		s.mu.Unlock()
+
+		fmt.Fprint(w, zoneRecordsResponse(records))
+
+	case "removeSubdomain":
+		key := s.key(call)
+
+		s.mu.Lock()
+		delete(s.records, key)
+		s.mu.Unlock()
+
+		fmt.Fprint(w, okResponse())
+
+	default:
+		http.Error(w, "unsupported method: "+call.MethodName, http.StatusBadRequest)
+	}
+}
+
+func okResponse() string {
+	return `<?xml version="1.0"?><methodResponse><params><param><value><string>OK</string></value></param></params></methodResponse>`
+}
+
+func zoneRecordsResponse(records []fakeRecord) string {
+	var members string
+	for _, rec := range records {
+		members += fmt.Sprintf(`<value><struct>
+			<member><name>type</name><value><string>TXT</string></value></member>
+			<member><name>ttl</name><value><int>300</int></value></member>
+			<member><name>priority</name><value><int>0</int></value></member>
+			<member><name>rdata</name><value><string>%s</string></value></member>
+			<member><name>record_id</name><value><int>%d</int></value></member>
+		</struct></value>`, rec.rdata, rec.recordID)
+	}
+
+	return fmt.Sprintf(`<?xml version="1.0"?><methodResponse><params><param><value><array><data>%s</data></array></value></param></params></methodResponse>`, members)
+}
+
+// TestDNSProvider_concurrentPresentAndCleanUp exercises the scenario chunk0-5 was written for:
+// two authorizations (e.g. the apex and a wildcard) resolving to the identical
+// _acme-challenge.example.se FQDN, presented concurrently. Each must get its own TXT record,
+// and each CleanUp must remove only the record it is responsible for.
+func TestDNSProvider_concurrentPresentAndCleanUp(t *testing.T) {
+	server := httptest.NewServer(newFakeLoopiaServer())
+	defer server.Close()
+
+	config := NewDefaultConfig()
+	config.APIUser = "user"
+	config.APIPassword = "pwd"
+	config.APIURL = server.URL
+	config.HTTPClient = &http.Client{Timeout: 5 * time.Second}
+
+	provider, err := NewDNSProviderConfig(config)
+	if err != nil {
+		t.Fatalf("NewDNSProviderConfig() error = %v", err)
+	}
+
+	const fqdn = "_acme-challenge.example.se."
+	values := []string{"value-for-apex", "value-for-wildcard"}
+
+	var wg sync.WaitGroup
+	for _, value := range values {
+		wg.Add(1)
+		go func(value string) {
+			defer wg.Done()
+			if err := provider.present("example.se", "_acme-challenge", fqdn, value); err != nil {
+				t.Errorf("present(%q) error = %v", value, err)
+			}
+		}(value)
+	}
+	wg.Wait()
+
+	records, err := provider.client.GetZoneRecords("example.se", "_acme-challenge")
+	if err != nil {
+		t.Fatalf("GetZoneRecords() error = %v", err)
+	}
+
+	if len(records) != len(values) {
+		t.Fatalf("got %d records, want %d", len(records), len(values))
+	}
+
+	for _, value := range values {
+		var found bool
+		for _, record := range records {
+			if record.RData == value {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("no record found for %q", value)
+		}
+	}
+
+	for i, value := range values {
+		if err := provider.cleanUp("example.se", "_acme-challenge", fqdn, value); err != nil {
+			t.Fatalf("cleanUp(%q) error = %v", value, err)
+		}
+
+		records, err := provider.client.GetZoneRecords("example.se", "_acme-challenge")
+		if err != nil {
+			t.Fatalf("GetZoneRecords() error = %v", err)
+		}
+
+		remaining := len(values) - i - 1
+		if len(records) != remaining {
+			t.Fatalf("after cleanUp(%q): got %d records, want %d", value, len(records), remaining)
+		}
+
+		for _, record := range records {
+			if record.RData == value {
+				t.Errorf("cleanUp(%q) left its own record behind", value)
+			}
+		}
+	}
+}